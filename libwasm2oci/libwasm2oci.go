@@ -2,19 +2,128 @@ package main
 
 import (
 	"C"
+	"encoding/json"
+	"sync"
 
 	"github.com/engineerd/wasm-to-oci/pkg/oci"
 	log "github.com/sirupsen/logrus"
 )
 
+// lastErr holds the error from the most recently failed exported call, so
+// C/Python/Node callers can retrieve more than a bare status code through
+// LastError.
+var (
+	lastErrMu sync.Mutex
+	lastErr   error
+)
+
+// setLastErr records err as the most recent failure and logs it, mirroring
+// the status code returned to the caller across the FFI boundary.
+func setLastErr(msg string, err error) {
+	lastErrMu.Lock()
+	lastErr = err
+	lastErrMu.Unlock()
+
+	log.Infof("%s: %v", msg, err)
+}
+
 //export Pull
 func Pull(ref, outFile string) int64 {
 	if err := oci.Pull(ref, outFile); err != nil {
-		log.Infof("cannot pull module: %v", err)
+		setLastErr("cannot pull module", err)
+		return 1
+	}
+
+	return 0
+}
+
+// PullWithOptions is the options-aware counterpart to Pull. optionsJSON is
+// a JSON-encoded oci.Options blob covering basic-auth/bearer credentials,
+// a custom CA bundle, plain-http/insecure toggles, a media type override,
+// and a path to write the pulled manifest to. An empty optionsJSON behaves
+// like Pull.
+//export PullWithOptions
+func PullWithOptions(ref, outFile, optionsJSON string) int64 {
+	var opts oci.Options
+	if optionsJSON != "" {
+		if err := json.Unmarshal([]byte(optionsJSON), &opts); err != nil {
+			setLastErr("cannot parse pull options", err)
+			return 1
+		}
+	}
+
+	if err := oci.PullWithOptions(ref, outFile, opts); err != nil {
+		setLastErr("cannot pull module", err)
+		return 1
+	}
+
+	return 0
+}
+
+// PullGraph fetches ref and the full closure of its dependency graph into
+// outDir, writing a lock.json that records the digest each logical
+// import resolved to.
+//export PullGraph
+func PullGraph(ref, outDir string) int64 {
+	if err := oci.PullGraph(ref, outDir); err != nil {
+		setLastErr("cannot pull module graph", err)
+		return 1
+	}
+
+	return 0
+}
+
+//export Push
+func Push(ref, file string) int64 {
+	if err := oci.Push(ref, file); err != nil {
+		setLastErr("cannot push module", err)
+		return 1
+	}
+
+	return 0
+}
+
+// PushWithOptions is the options-aware counterpart to Push. optionsJSON is
+// a JSON-encoded oci.Options blob; its MediaType field overrides the media
+// type the module is tagged with. An empty optionsJSON behaves like Push.
+//export PushWithOptions
+func PushWithOptions(ref, file, optionsJSON string) int64 {
+	var opts oci.Options
+	if optionsJSON != "" {
+		if err := json.Unmarshal([]byte(optionsJSON), &opts); err != nil {
+			setLastErr("cannot parse push options", err)
+			return 1
+		}
+	}
+
+	if err := oci.PushWithOptions(ref, file, opts); err != nil {
+		setLastErr("cannot push module", err)
 		return 1
 	}
 
 	return 0
 }
 
+//export Login
+func Login(registry, username, password string) int64 {
+	if err := oci.Login(registry, username, password); err != nil {
+		setLastErr("cannot login", err)
+		return 1
+	}
+
+	return 0
+}
+
+//export LastError
+func LastError() *C.char {
+	lastErrMu.Lock()
+	defer lastErrMu.Unlock()
+
+	if lastErr == nil {
+		return C.CString("")
+	}
+
+	return C.CString(lastErr.Error())
+}
+
 func main() {}
@@ -0,0 +1,161 @@
+package oci
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/containerd/containerd/remotes"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// ImportsAnnotation is the OCI manifest annotation listing a module's
+// direct dependencies, as a JSON object mapping each logical import name
+// to the "registry/name@sha256:digest" ref it resolves to.
+const ImportsAnnotation = "org.webassembly.imports"
+
+// PullGraph fetches ref, treated as the root of a dependency DAG, into
+// outDir. The manifest's ImportsAnnotation is walked recursively to pull
+// every transitive dependency, deduplicating by digest and failing with a
+// descriptive error if a cycle is found. A lock.json mapping every
+// logical import name encountered to the digest it resolved to is written
+// to outDir. PullGraph does not read an existing lock.json: every call
+// resolves each ref afresh.
+func PullGraph(ref, outDir string) error {
+	return PullGraphWithOptions(ref, outDir, Options{})
+}
+
+// PullGraphWithOptions behaves like PullGraph but authenticates and
+// transfers data per opts, the same as PullWithOptions.
+func PullGraphWithOptions(ref, outDir string, opts Options) error {
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return fmt.Errorf("cannot create %s: %w", outDir, err)
+	}
+
+	resolver, err := newResolverWithOptions(opts)
+	if err != nil {
+		return fmt.Errorf("cannot configure registry client: %w", err)
+	}
+
+	g := &grapher{
+		ctx:      context.Background(),
+		resolver: resolver,
+		opts:     opts,
+		outDir:   outDir,
+		visited:  map[string]bool{},
+		visiting: map[string]bool{},
+		lock:     map[string]string{},
+	}
+
+	if err := g.pull("", ref); err != nil {
+		return err
+	}
+
+	return g.writeLock()
+}
+
+// grapher walks a module's dependency DAG, pulling each node exactly once
+// and detecting cycles along the way.
+type grapher struct {
+	ctx      context.Context
+	resolver remotes.Resolver
+	opts     Options
+	outDir   string
+
+	visited  map[string]bool   // digest -> already pulled
+	visiting map[string]bool   // digest -> currently being resolved, for cycle detection
+	lock     map[string]string // logical import name -> resolved digest
+}
+
+// pull resolves and, unless already visited, fetches ref, then recurses
+// into its declared imports. importName is the logical name ref was
+// imported under by its parent, or "" for the root.
+//
+// visiting/visited are keyed by the resolved digest rather than the ref
+// string: two different refs (e.g. a tag and its @sha256:... digest) can
+// name the same node, and a cycle routed through such an alias must still
+// be reported rather than silently treated as "already pulled".
+func (g *grapher) pull(importName, ref string) error {
+	name, desc, err := g.resolver.Resolve(g.ctx, ref)
+	if err != nil {
+		return fmt.Errorf("cannot resolve ref %q: %w", ref, err)
+	}
+
+	dgst := desc.Digest.String()
+	if importName != "" {
+		g.lock[importName] = dgst
+	}
+
+	if g.visiting[dgst] {
+		return fmt.Errorf("import cycle detected: %q (%s) depends on itself transitively", ref, dgst)
+	}
+	if g.visited[dgst] {
+		return nil
+	}
+	g.visiting[dgst] = true
+	defer delete(g.visiting, dgst)
+	g.visited[dgst] = true
+
+	fetcher, err := g.resolver.Fetcher(g.ctx, name)
+	if err != nil {
+		return fmt.Errorf("cannot create fetcher for %q: %w", name, err)
+	}
+
+	manifest, err := fetchManifest(g.ctx, fetcher, desc)
+	if err != nil {
+		return err
+	}
+
+	layer, err := findLayer(manifest, g.opts.mediaType())
+	if err != nil {
+		return err
+	}
+
+	outFile := filepath.Join(g.outDir, desc.Digest.Encoded()+".wasm")
+	if err := fetchToFile(g.ctx, fetcher, layer, outFile); err != nil {
+		return err
+	}
+
+	imports, err := parseImports(manifest)
+	if err != nil {
+		return fmt.Errorf("ref %q: %w", ref, err)
+	}
+
+	for childName, childRef := range imports {
+		if err := g.pull(childName, childRef); err != nil {
+			return fmt.Errorf("%s imports %s: %w", ref, childRef, err)
+		}
+	}
+
+	return nil
+}
+
+// parseImports extracts the logical-name-to-ref map from manifest's
+// ImportsAnnotation, if present.
+func parseImports(manifest ocispec.Manifest) (map[string]string, error) {
+	raw, ok := manifest.Annotations[ImportsAnnotation]
+	if !ok || raw == "" {
+		return nil, nil
+	}
+
+	var imports map[string]string
+	if err := json.Unmarshal([]byte(raw), &imports); err != nil {
+		return nil, fmt.Errorf("cannot parse %s annotation: %w", ImportsAnnotation, err)
+	}
+
+	return imports, nil
+}
+
+// writeLock writes the accumulated logical-name-to-digest map to
+// lock.json in outDir.
+func (g *grapher) writeLock() error {
+	b, err := json.MarshalIndent(g.lock, "", "  ")
+	if err != nil {
+		return fmt.Errorf("cannot encode lock.json: %w", err)
+	}
+
+	return ioutil.WriteFile(filepath.Join(g.outDir, "lock.json"), b, 0644)
+}
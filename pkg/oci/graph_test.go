@@ -0,0 +1,207 @@
+package oci
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/containerd/containerd/remotes"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// fakeNode is one entry in a synthetic registry used to drive grapher
+// without any network access. digest is the node's canonical identity:
+// two different ref strings backed by nodes with the same digest are
+// aliases of one another, the way a tag and its @sha256:... digest are.
+type fakeNode struct {
+	digest  string
+	imports map[string]string // logical import name -> ref
+}
+
+// fakeRegistry implements remotes.Resolver (and, through fakeFetcher,
+// remotes.Fetcher) over an in-memory map of fakeNodes, and counts how many
+// times each blob is fetched so tests can assert on dedup behavior.
+type fakeRegistry struct {
+	nodes      map[string]fakeNode
+	blobs      map[string][]byte
+	fetchCount map[string]int
+}
+
+func newFakeRegistry(nodes map[string]fakeNode) *fakeRegistry {
+	return &fakeRegistry{
+		nodes:      nodes,
+		blobs:      map[string][]byte{},
+		fetchCount: map[string]int{},
+	}
+}
+
+func (r *fakeRegistry) Resolve(ctx context.Context, ref string) (string, ocispec.Descriptor, error) {
+	node, ok := r.nodes[ref]
+	if !ok {
+		return "", ocispec.Descriptor{}, fmt.Errorf("no such ref %q", ref)
+	}
+
+	layerContent := []byte("wasm:" + node.digest)
+	layerDigest := digest.FromString("layer:" + node.digest)
+	r.blobs[layerDigest.String()] = layerContent
+
+	manifest := ocispec.Manifest{
+		Layers: []ocispec.Descriptor{{
+			MediaType: MediaType,
+			Digest:    layerDigest,
+			Size:      int64(len(layerContent)),
+		}},
+	}
+	if len(node.imports) > 0 {
+		b, err := json.Marshal(node.imports)
+		if err != nil {
+			return "", ocispec.Descriptor{}, err
+		}
+		manifest.Annotations = map[string]string{ImportsAnnotation: string(b)}
+	}
+
+	manifestBytes, err := json.Marshal(manifest)
+	if err != nil {
+		return "", ocispec.Descriptor{}, err
+	}
+	manifestDigest := digest.FromString("manifest:" + node.digest)
+	r.blobs[manifestDigest.String()] = manifestBytes
+
+	return ref, ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    manifestDigest,
+		Size:      int64(len(manifestBytes)),
+	}, nil
+}
+
+func (r *fakeRegistry) Fetcher(ctx context.Context, name string) (remotes.Fetcher, error) {
+	return fakeFetcher{r}, nil
+}
+
+func (r *fakeRegistry) Pusher(ctx context.Context, ref string) (remotes.Pusher, error) {
+	return nil, fmt.Errorf("push not supported by fakeRegistry")
+}
+
+type fakeFetcher struct {
+	r *fakeRegistry
+}
+
+func (f fakeFetcher) Fetch(ctx context.Context, desc ocispec.Descriptor) (io.ReadCloser, error) {
+	b, ok := f.r.blobs[desc.Digest.String()]
+	if !ok {
+		return nil, fmt.Errorf("no such blob %s", desc.Digest)
+	}
+	f.r.fetchCount[desc.Digest.String()]++
+
+	return ioutil.NopCloser(bytes.NewReader(b)), nil
+}
+
+func newTestGrapher(t *testing.T, reg *fakeRegistry) *grapher {
+	t.Helper()
+
+	return &grapher{
+		ctx:      context.Background(),
+		resolver: reg,
+		opts:     Options{},
+		outDir:   t.TempDir(),
+		visited:  map[string]bool{},
+		visiting: map[string]bool{},
+		lock:     map[string]string{},
+	}
+}
+
+func TestGrapherPull_DetectsDirectCycle(t *testing.T) {
+	reg := newFakeRegistry(map[string]fakeNode{
+		"a": {digest: "a", imports: map[string]string{"b": "b"}},
+		"b": {digest: "b", imports: map[string]string{"a": "a"}},
+	})
+	g := newTestGrapher(t, reg)
+
+	err := g.pull("", "a")
+	if err == nil {
+		t.Fatal("expected an error for the a -> b -> a cycle, got nil")
+	}
+	if !strings.Contains(err.Error(), "cycle") {
+		t.Fatalf("expected a cycle error, got: %v", err)
+	}
+}
+
+// TestGrapherPull_DetectsCycleThroughDigestAlias covers a cycle that
+// re-enters the in-progress node via a different ref string resolving to
+// the same digest (e.g. a tag vs. its @sha256:... form) rather than the
+// exact same ref text.
+func TestGrapherPull_DetectsCycleThroughDigestAlias(t *testing.T) {
+	reg := newFakeRegistry(map[string]fakeNode{
+		"a:v1":           {digest: "a", imports: map[string]string{"b": "b"}},
+		"a@sha256:alias": {digest: "a", imports: map[string]string{"b": "b"}},
+		"b":              {digest: "b", imports: map[string]string{"back": "a@sha256:alias"}},
+	})
+	g := newTestGrapher(t, reg)
+
+	err := g.pull("", "a:v1")
+	if err == nil {
+		t.Fatal("expected an error for the digest-aliased cycle, got nil")
+	}
+	if !strings.Contains(err.Error(), "cycle") {
+		t.Fatalf("expected a cycle error, got: %v", err)
+	}
+}
+
+func TestGrapherPull_DiamondDependencyIsPulledOnce(t *testing.T) {
+	reg := newFakeRegistry(map[string]fakeNode{
+		"root":   {digest: "root", imports: map[string]string{"left": "left", "right": "right"}},
+		"left":   {digest: "left", imports: map[string]string{"shared": "shared"}},
+		"right":  {digest: "right", imports: map[string]string{"shared": "shared"}},
+		"shared": {digest: "shared"},
+	})
+	g := newTestGrapher(t, reg)
+
+	if err := g.pull("", "root"); err != nil {
+		t.Fatalf("pull failed: %v", err)
+	}
+
+	sharedManifestDigest := digest.FromString("manifest:shared").String()
+	if got := reg.fetchCount[sharedManifestDigest]; got != 1 {
+		t.Errorf("shared manifest fetched %d times, want 1 (dedup by digest)", got)
+	}
+
+	sharedLayerDigest := digest.FromString("layer:shared").String()
+	if got := reg.fetchCount[sharedLayerDigest]; got != 1 {
+		t.Errorf("shared layer fetched %d times, want 1 (dedup by digest)", got)
+	}
+
+	if err := g.writeLock(); err != nil {
+		t.Fatalf("writeLock failed: %v", err)
+	}
+
+	raw, err := ioutil.ReadFile(filepath.Join(g.outDir, "lock.json"))
+	if err != nil {
+		t.Fatalf("cannot read lock.json: %v", err)
+	}
+
+	var lock map[string]string
+	if err := json.Unmarshal(raw, &lock); err != nil {
+		t.Fatalf("cannot decode lock.json: %v", err)
+	}
+
+	want := map[string]string{
+		"left":   digest.FromString("manifest:left").String(),
+		"right":  digest.FromString("manifest:right").String(),
+		"shared": sharedManifestDigest,
+	}
+	for name, wantDigest := range want {
+		if got := lock[name]; got != wantDigest {
+			t.Errorf("lock.json[%q] = %q, want %q", name, got, wantDigest)
+		}
+	}
+	if len(lock) != len(want) {
+		t.Errorf("lock.json has %d entries, want %d: %v", len(lock), len(want), lock)
+	}
+}
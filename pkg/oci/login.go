@@ -0,0 +1,160 @@
+package oci
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Login verifies that username/password authenticate successfully against
+// registry, so callers can fail fast before attempting a pull or push. It
+// follows the same challenge/response flow a docker client uses: probe the
+// v2 API, and if the registry demands bearer auth, trade the credentials
+// for a token at the realm it advertises.
+func Login(registry, username, password string) error {
+	ctx := context.Background()
+	base := "https://" + registry + "/v2/"
+
+	resp, err := probeV2(ctx, base, "")
+	if err != nil {
+		return fmt.Errorf("cannot reach %q: %w", registry, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusUnauthorized:
+		token, err := fetchBearerToken(ctx, resp.Header.Get("WWW-Authenticate"), username, password)
+		if err != nil {
+			return fmt.Errorf("cannot authenticate with %q: %w", registry, err)
+		}
+
+		resp2, err := probeV2(ctx, base, token)
+		if err != nil {
+			return fmt.Errorf("cannot reach %q: %w", registry, err)
+		}
+		defer resp2.Body.Close()
+
+		if resp2.StatusCode != http.StatusOK {
+			return fmt.Errorf("cannot authenticate with %q: invalid credentials", registry)
+		}
+
+		return nil
+	default:
+		return fmt.Errorf("cannot authenticate with %q: unexpected status %s", registry, resp.Status)
+	}
+}
+
+// probeV2 issues an unauthenticated (or bearer-authenticated, if token is
+// set) GET against a registry's v2 API root.
+func probeV2(ctx context.Context, url, token string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	return http.DefaultClient.Do(req)
+}
+
+// fetchBearerToken trades username/password for a bearer token at the
+// realm advertised by a `WWW-Authenticate: Bearer ...` challenge.
+func fetchBearerToken(ctx context.Context, challenge, username, password string) (string, error) {
+	params, ok := parseBearerChallenge(challenge)
+	if !ok {
+		return "", fmt.Errorf("unsupported auth challenge %q", challenge)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, params["realm"], nil)
+	if err != nil {
+		return "", err
+	}
+
+	q := req.URL.Query()
+	if service := params["service"]; service != "" {
+		q.Set("service", service)
+	}
+	if scope := params["scope"]; scope != "" {
+		q.Set("scope", scope)
+	}
+	req.URL.RawQuery = q.Encode()
+	req.SetBasicAuth(username, password)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token request returned %s", resp.Status)
+	}
+
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("cannot decode token response: %w", err)
+	}
+
+	if body.Token != "" {
+		return body.Token, nil
+	}
+
+	return body.AccessToken, nil
+}
+
+// parseBearerChallenge extracts realm/service/scope from a
+// `Bearer realm="...",service="...",scope="..."` WWW-Authenticate header.
+func parseBearerChallenge(challenge string) (map[string]string, bool) {
+	const prefix = "Bearer "
+	if !strings.HasPrefix(challenge, prefix) {
+		return nil, false
+	}
+
+	params := map[string]string{}
+	for _, part := range splitChallengeParams(strings.TrimPrefix(challenge, prefix)) {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		params[kv[0]] = strings.Trim(kv[1], `"`)
+	}
+
+	if params["realm"] == "" {
+		return nil, false
+	}
+
+	return params, true
+}
+
+// splitChallengeParams splits a comma-separated list of key="value" pairs
+// on top-level commas only, so a comma inside a quoted value (as in a
+// multi-action scope like `scope="repo:name:pull,push"`) does not break
+// the value apart.
+func splitChallengeParams(s string) []string {
+	var parts []string
+	inQuotes := false
+	start := 0
+
+	for i, r := range s {
+		switch r {
+		case '"':
+			inQuotes = !inQuotes
+		case ',':
+			if !inQuotes {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	parts = append(parts, s[start:])
+
+	return parts
+}
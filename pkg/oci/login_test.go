@@ -0,0 +1,106 @@
+package oci
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitChallengeParams(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want []string
+	}{
+		{
+			name: "simple",
+			in:   `realm="https://auth.example.com/token",service="registry.example.com"`,
+			want: []string{`realm="https://auth.example.com/token"`, `service="registry.example.com"`},
+		},
+		{
+			name: "comma inside quoted scope",
+			in:   `realm="https://auth.example.com/token",service="registry.example.com",scope="repository:name:pull,push"`,
+			want: []string{
+				`realm="https://auth.example.com/token"`,
+				`service="registry.example.com"`,
+				`scope="repository:name:pull,push"`,
+			},
+		},
+		{
+			name: "single param",
+			in:   `realm="https://auth.example.com/token"`,
+			want: []string{`realm="https://auth.example.com/token"`},
+		},
+		{
+			name: "empty",
+			in:   "",
+			want: []string{""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitChallengeParams(tt.in)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitChallengeParams(%q) = %#v, want %#v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseBearerChallenge(t *testing.T) {
+	tests := []struct {
+		name      string
+		challenge string
+		wantOK    bool
+		want      map[string]string
+	}{
+		{
+			name:      "realm, service and multi-action scope",
+			challenge: `Bearer realm="https://auth.example.com/token",service="registry.example.com",scope="repository:name:pull,push"`,
+			wantOK:    true,
+			want: map[string]string{
+				"realm":   "https://auth.example.com/token",
+				"service": "registry.example.com",
+				"scope":   "repository:name:pull,push",
+			},
+		},
+		{
+			name:      "realm only",
+			challenge: `Bearer realm="https://auth.example.com/token"`,
+			wantOK:    true,
+			want: map[string]string{
+				"realm": "https://auth.example.com/token",
+			},
+		},
+		{
+			name:      "missing realm",
+			challenge: `Bearer service="registry.example.com"`,
+			wantOK:    false,
+		},
+		{
+			name:      "non-Bearer challenge",
+			challenge: `Basic realm="registry.example.com"`,
+			wantOK:    false,
+		},
+		{
+			name:      "empty challenge",
+			challenge: "",
+			wantOK:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseBearerChallenge(tt.challenge)
+			if ok != tt.wantOK {
+				t.Fatalf("parseBearerChallenge(%q) ok = %v, want %v", tt.challenge, ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseBearerChallenge(%q) = %#v, want %#v", tt.challenge, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+// Package oci implements the subset of OCI image distribution used to
+// store and retrieve WebAssembly modules as artifacts in a container
+// registry.
+package oci
+
+import (
+	"github.com/containerd/containerd/remotes"
+	"github.com/containerd/containerd/remotes/docker"
+)
+
+const (
+	// MediaType is the reserved media type for a WASM module stored as an
+	// OCI image layer.
+	MediaType = "application/vnd.wasm.content.layer.v1+wasm"
+
+	// ConfigMediaType is the media type used for the image config of a
+	// WASM OCI artifact.
+	ConfigMediaType = "application/vnd.wasm.config.v1+json"
+)
+
+// newResolver builds a docker remotes.Resolver, authenticating with
+// username/password when either is set.
+func newResolver(username, password string) remotes.Resolver {
+	var credentials func(string) (string, string, error)
+	if username != "" || password != "" {
+		credentials = func(string) (string, string, error) {
+			return username, password, nil
+		}
+	}
+
+	return docker.NewResolver(docker.ResolverOptions{
+		Credentials: credentials,
+	})
+}
@@ -0,0 +1,104 @@
+package oci
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/containerd/containerd/remotes"
+	"github.com/containerd/containerd/remotes/docker"
+)
+
+// errInvalidCABundle is returned when CAFile does not contain any usable
+// PEM-encoded certificates.
+var errInvalidCABundle = errors.New("oci: no certificates found in CA bundle")
+
+// Options controls how PullWithOptions and PushWithOptions authenticate
+// against, and transfer data with, an OCI registry.
+type Options struct {
+	// Username and Password are used for HTTP basic auth against the
+	// registry.
+	Username string `json:"username,omitempty"`
+	// Password is used together with Username for HTTP basic auth.
+	Password string `json:"password,omitempty"`
+	// Token is used for bearer-token auth instead of Username/Password.
+	Token string `json:"token,omitempty"`
+
+	// PlainHTTP talks to the registry over plain HTTP instead of HTTPS.
+	PlainHTTP bool `json:"plainHttp,omitempty"`
+	// Insecure skips verification of the registry's HTTPS certificate.
+	// It has no effect when PlainHTTP is set.
+	Insecure bool `json:"insecure,omitempty"`
+	// CAFile is the path to a PEM-encoded CA bundle trusted in addition
+	// to the system roots when verifying the registry's certificate.
+	CAFile string `json:"caFile,omitempty"`
+
+	// MediaType overrides the media type used to locate the module layer
+	// on pull, or to tag it on push. Defaults to MediaType.
+	MediaType string `json:"mediaType,omitempty"`
+
+	// ManifestFile, when set, receives the pulled ref's OCI manifest,
+	// decoded and re-encoded as indented JSON, so callers can inspect its
+	// annotations and config descriptor.
+	ManifestFile string `json:"manifestFile,omitempty"`
+}
+
+func (o Options) mediaType() string {
+	if o.MediaType != "" {
+		return o.MediaType
+	}
+	return MediaType
+}
+
+func (o Options) credentials() func(string) (string, string, error) {
+	if o.Token != "" {
+		return func(string) (string, string, error) {
+			return "", o.Token, nil
+		}
+	}
+	if o.Username != "" || o.Password != "" {
+		return func(string) (string, string, error) {
+			return o.Username, o.Password, nil
+		}
+	}
+	return nil
+}
+
+// newResolverWithOptions builds a docker remotes.Resolver configured from
+// opts, supporting the TLS and plain-HTTP knobs newResolver does not.
+func newResolverWithOptions(opts Options) (remotes.Resolver, error) {
+	client := http.DefaultClient
+
+	if opts.Insecure || opts.CAFile != "" {
+		tlsConfig := &tls.Config{InsecureSkipVerify: opts.Insecure}
+
+		if opts.CAFile != "" {
+			pool, err := x509.SystemCertPool()
+			if err != nil || pool == nil {
+				pool = x509.NewCertPool()
+			}
+
+			pem, err := ioutil.ReadFile(opts.CAFile)
+			if err != nil {
+				return nil, err
+			}
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, errInvalidCABundle
+			}
+
+			tlsConfig.RootCAs = pool
+		}
+
+		client = &http.Client{
+			Transport: &http.Transport{TLSClientConfig: tlsConfig},
+		}
+	}
+
+	return docker.NewResolver(docker.ResolverOptions{
+		Credentials: opts.credentials(),
+		Client:      client,
+		PlainHTTP:   opts.PlainHTTP,
+	}), nil
+}
@@ -0,0 +1,143 @@
+package oci
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/containerd/containerd/remotes"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// Pull fetches the WASM module referenced by ref from an OCI registry and
+// writes it to outFile. ref may include a tag or a digest
+// (registry/name@sha256:...) to pin an exact artifact.
+func Pull(ref, outFile string) error {
+	ctx := context.Background()
+	resolver := newResolver("", "")
+
+	name, desc, err := resolver.Resolve(ctx, ref)
+	if err != nil {
+		return fmt.Errorf("cannot resolve ref %q: %w", ref, err)
+	}
+
+	fetcher, err := resolver.Fetcher(ctx, name)
+	if err != nil {
+		return fmt.Errorf("cannot create fetcher for %q: %w", name, err)
+	}
+
+	manifest, err := fetchManifest(ctx, fetcher, desc)
+	if err != nil {
+		return err
+	}
+
+	layer, err := findLayer(manifest, MediaType)
+	if err != nil {
+		return err
+	}
+
+	return fetchToFile(ctx, fetcher, layer, outFile)
+}
+
+// PullWithOptions behaves like Pull but allows the caller to authenticate
+// against private registries, opt into plain HTTP or an untrusted TLS
+// certificate, override the expected module media type, and request the
+// manifest alongside the module so its annotations can be inspected.
+func PullWithOptions(ref, outFile string, opts Options) error {
+	ctx := context.Background()
+
+	resolver, err := newResolverWithOptions(opts)
+	if err != nil {
+		return fmt.Errorf("cannot configure registry client: %w", err)
+	}
+
+	name, desc, err := resolver.Resolve(ctx, ref)
+	if err != nil {
+		return fmt.Errorf("cannot resolve ref %q: %w", ref, err)
+	}
+
+	fetcher, err := resolver.Fetcher(ctx, name)
+	if err != nil {
+		return fmt.Errorf("cannot create fetcher for %q: %w", name, err)
+	}
+
+	manifest, err := fetchManifest(ctx, fetcher, desc)
+	if err != nil {
+		return err
+	}
+
+	if opts.ManifestFile != "" {
+		if err := writeManifest(opts.ManifestFile, manifest); err != nil {
+			return err
+		}
+	}
+
+	layer, err := findLayer(manifest, opts.mediaType())
+	if err != nil {
+		return err
+	}
+
+	return fetchToFile(ctx, fetcher, layer, outFile)
+}
+
+// writeManifest writes the JSON-encoded manifest to path.
+func writeManifest(path string, manifest ocispec.Manifest) error {
+	b, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("cannot encode manifest: %w", err)
+	}
+
+	return ioutil.WriteFile(path, b, 0644)
+}
+
+// fetchManifest retrieves and decodes the OCI manifest at desc.
+func fetchManifest(ctx context.Context, fetcher remotes.Fetcher, desc ocispec.Descriptor) (ocispec.Manifest, error) {
+	var manifest ocispec.Manifest
+
+	rc, err := fetcher.Fetch(ctx, desc)
+	if err != nil {
+		return manifest, fmt.Errorf("cannot fetch manifest: %w", err)
+	}
+	defer rc.Close()
+
+	if err := json.NewDecoder(rc).Decode(&manifest); err != nil {
+		return manifest, fmt.Errorf("cannot decode manifest: %w", err)
+	}
+
+	return manifest, nil
+}
+
+// findLayer returns the first layer in manifest matching mediaType.
+func findLayer(manifest ocispec.Manifest, mediaType string) (ocispec.Descriptor, error) {
+	for _, layer := range manifest.Layers {
+		if layer.MediaType == mediaType {
+			return layer, nil
+		}
+	}
+
+	return ocispec.Descriptor{}, fmt.Errorf("no layer with media type %q in manifest", mediaType)
+}
+
+// fetchToFile downloads desc and writes its content to outFile.
+func fetchToFile(ctx context.Context, fetcher remotes.Fetcher, desc ocispec.Descriptor, outFile string) error {
+	rc, err := fetcher.Fetch(ctx, desc)
+	if err != nil {
+		return fmt.Errorf("cannot fetch layer %s: %w", desc.Digest, err)
+	}
+	defer rc.Close()
+
+	f, err := os.Create(outFile)
+	if err != nil {
+		return fmt.Errorf("cannot create %s: %w", outFile, err)
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, rc); err != nil {
+		return fmt.Errorf("cannot write %s: %w", outFile, err)
+	}
+
+	return nil
+}
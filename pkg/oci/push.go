@@ -0,0 +1,124 @@
+package oci
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/containerd/remotes"
+	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// Push uploads file as a WASM module layer and publishes it to ref.
+func Push(ref, file string) error {
+	return PushWithOptions(ref, file, Options{})
+}
+
+// PushWithOptions behaves like Push but allows the caller to override the
+// media type the module is tagged with, and to authenticate against
+// private registries the same way PullWithOptions does.
+func PushWithOptions(ref, file string, opts Options) error {
+	ctx := context.Background()
+
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("cannot read %s: %w", file, err)
+	}
+
+	layer := ocispec.Descriptor{
+		MediaType: opts.mediaType(),
+		Digest:    digest.FromBytes(content),
+		Size:      int64(len(content)),
+	}
+
+	config, configBytes, err := emptyConfig()
+	if err != nil {
+		return err
+	}
+
+	manifest, manifestBytes, err := buildManifest(config, layer)
+	if err != nil {
+		return err
+	}
+
+	resolver, err := newResolverWithOptions(opts)
+	if err != nil {
+		return fmt.Errorf("cannot configure registry client: %w", err)
+	}
+
+	pusher, err := resolver.Pusher(ctx, ref)
+	if err != nil {
+		return fmt.Errorf("cannot create pusher for %q: %w", ref, err)
+	}
+
+	if err := pushBlob(ctx, pusher, config, configBytes); err != nil {
+		return err
+	}
+	if err := pushBlob(ctx, pusher, layer, content); err != nil {
+		return err
+	}
+	if err := pushBlob(ctx, pusher, manifest, manifestBytes); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// emptyConfig builds the minimal OCI image config used for a WASM
+// artifact, which carries no runtime configuration of its own.
+func emptyConfig() (ocispec.Descriptor, []byte, error) {
+	b, err := json.Marshal(struct{}{})
+	if err != nil {
+		return ocispec.Descriptor{}, nil, fmt.Errorf("cannot encode config: %w", err)
+	}
+
+	return ocispec.Descriptor{
+		MediaType: ConfigMediaType,
+		Digest:    digest.FromBytes(b),
+		Size:      int64(len(b)),
+	}, b, nil
+}
+
+// buildManifest assembles the OCI manifest for a single-layer WASM
+// artifact.
+func buildManifest(config, layer ocispec.Descriptor) (ocispec.Descriptor, []byte, error) {
+	manifest := ocispec.Manifest{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		Config:    config,
+		Layers:    []ocispec.Descriptor{layer},
+	}
+
+	b, err := json.Marshal(manifest)
+	if err != nil {
+		return ocispec.Descriptor{}, nil, fmt.Errorf("cannot encode manifest: %w", err)
+	}
+
+	return ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    digest.FromBytes(b),
+		Size:      int64(len(b)),
+	}, b, nil
+}
+
+// pushBlob uploads content for desc via pusher, treating an
+// already-exists response as success.
+func pushBlob(ctx context.Context, pusher remotes.Pusher, desc ocispec.Descriptor, content []byte) error {
+	w, err := pusher.Push(ctx, desc)
+	if err != nil {
+		if errdefs.IsAlreadyExists(err) {
+			return nil
+		}
+		return fmt.Errorf("cannot push %s: %w", desc.Digest, err)
+	}
+	defer w.Close()
+
+	if _, err := w.Write(content); err != nil {
+		return fmt.Errorf("cannot write %s: %w", desc.Digest, err)
+	}
+
+	return w.Commit(ctx, desc.Size, desc.Digest)
+}